@@ -0,0 +1,97 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+// ArtifactBackend builds and inspects one kind of artifact (Docker, Bazel,
+// Jib, ...). Builders like the local daemon builder dispatch to an
+// ArtifactBackend instead of switching on artifact type directly, so that
+// new artifact types can be added without editing the builder itself.
+type ArtifactBackend interface {
+	// Name is a short, human-readable identifier used in error messages.
+	Name() string
+
+	// Matches reports whether this backend handles the given artifact.
+	Matches(artifact *latest.Artifact) bool
+
+	// Build builds the artifact and returns its repo digest when pushing, or
+	// its local image ID otherwise.
+	Build(ctx context.Context, out io.Writer, artifact *latest.Artifact, tag string) (string, error)
+
+	// Dependencies returns the file paths the artifact's build depends on.
+	Dependencies(ctx context.Context, artifact *latest.Artifact) ([]string, error)
+
+	// SyncMap returns the artifact's file sync map, or ErrSyncMapNotSupported.
+	SyncMap(ctx context.Context, artifact *latest.Artifact) (map[string][]string, error)
+}
+
+// ErrUnknownArtifactType is returned when no registered backend matches an
+// artifact's type.
+type ErrUnknownArtifactType struct {
+	ArtifactName string
+}
+
+func (e ErrUnknownArtifactType) Error() string {
+	return fmt.Sprintf("undefined artifact type for %q: no registered build backend matches", e.ArtifactName)
+}
+
+// ErrSyncMapNotSupported is returned by backends whose artifact type has no
+// notion of file sync (e.g. Bazel, custom build scripts).
+type ErrSyncMapNotSupported struct{}
+
+func (e ErrSyncMapNotSupported) Error() string {
+	return "sync maps are not supported for this artifact type"
+}
+
+var (
+	backendsMu sync.Mutex
+	backends   []ArtifactBackend
+)
+
+// RegisterBackend registers a global ArtifactBackend, for artifact types that
+// don't need to be wired up with builder-specific state (a registry client,
+// a daemon connection, ...). Backends are consulted in registration order;
+// the first Matches wins. Builders with per-instance state, like the local
+// daemon builder, hand that state to the registered backends through each
+// build's context (see WithEnvironment).
+func RegisterBackend(backend ArtifactBackend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends = append(backends, backend)
+}
+
+// BackendFor returns the globally registered backend that matches the given
+// artifact, or ErrUnknownArtifactType if none does.
+func BackendFor(artifact *latest.Artifact) (ArtifactBackend, error) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	for _, backend := range backends {
+		if backend.Matches(artifact) {
+			return backend, nil
+		}
+	}
+	return nil, ErrUnknownArtifactType{ArtifactName: artifact.ImageName}
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+func TestBackendForUnknownArtifactType(t *testing.T) {
+	_, err := BackendFor(&latest.Artifact{ImageName: "no-such-backend-matches-this"})
+
+	unknown, ok := err.(ErrUnknownArtifactType)
+	if !ok {
+		t.Fatalf("got error %v (%T), want ErrUnknownArtifactType", err, err)
+	}
+	if unknown.ArtifactName != "no-such-backend-matches-this" {
+		t.Errorf("got ArtifactName %q, want %q", unknown.ArtifactName, "no-such-backend-matches-this")
+	}
+}
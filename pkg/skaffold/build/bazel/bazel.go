@@ -0,0 +1,120 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bazel
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+// Build runs `bazel build` for a's target, loads the resulting image tarball
+// into the local docker daemon, and tags it. Bazel artifacts build directly
+// to a self-contained tarball, so there's no --platform/cache-flag threading
+// the way docker builds have.
+func Build(ctx context.Context, out io.Writer, workspace string, a *latest.BazelArtifact, tag string, env build.Environment) (string, error) {
+	buildArgs := append([]string{"build"}, a.BuildArgs...)
+	buildArgs = append(buildArgs, a.BuildTarget)
+
+	cmd := exec.CommandContext(ctx, "bazel", buildArgs...)
+	cmd.Dir = workspace
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrap(err, "running bazel build")
+	}
+
+	tarPath := filepath.Join(workspace, outputTarPath(a.BuildTarget))
+
+	loadOut, err := exec.CommandContext(ctx, "docker", "load", "-i", tarPath).CombinedOutput()
+	if err != nil {
+		return "", errors.Wrapf(err, "docker load: %s", string(loadOut))
+	}
+
+	imageID, err := parseLoadedImageID(string(loadOut))
+	if err != nil {
+		return "", err
+	}
+
+	if tagOut, err := exec.CommandContext(ctx, "docker", "tag", imageID, tag).CombinedOutput(); err != nil {
+		return "", errors.Wrapf(err, "docker tag: %s", string(tagOut))
+	}
+
+	if env.PushImages {
+		return env.LocalDocker.Push(ctx, out, tag)
+	}
+	return imageID, nil
+}
+
+// outputTarPath derives the bazel-bin path rules_docker writes a target's
+// image tarball to, e.g. "//app:app_image" -> "bazel-bin/app/app_image.tar".
+func outputTarPath(buildTarget string) string {
+	target := strings.TrimPrefix(buildTarget, "//")
+	pkg, name, found := strings.Cut(target, ":")
+	if !found {
+		name = pkg
+	}
+	return filepath.Join("bazel-bin", pkg, name+".tar")
+}
+
+// parseLoadedImageID extracts the loaded image's ID from `docker load`'s
+// "Loaded image ID: sha256:..." output line.
+func parseLoadedImageID(dockerLoadOutput string) (string, error) {
+	for _, line := range strings.Split(dockerLoadOutput, "\n") {
+		if idx := strings.Index(line, "sha256:"); idx != -1 {
+			return strings.TrimSpace(line[idx:]), nil
+		}
+	}
+	return "", errors.Errorf("could not find an image ID in docker load output: %s", dockerLoadOutput)
+}
+
+// GetDependencies returns the source files a's target depends on, via a
+// bazel query over the target's transitive source file dependencies.
+func GetDependencies(ctx context.Context, workspace string, a *latest.BazelArtifact) ([]string, error) {
+	query := "kind('source file', deps(" + a.BuildTarget + "))"
+	cmd := exec.CommandContext(ctx, "bazel", "query", query, "--output", "label")
+	cmd.Dir = workspace
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "querying bazel dependencies")
+	}
+
+	var deps []string
+	for _, label := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if label == "" {
+			continue
+		}
+		deps = append(deps, labelToPath(label))
+	}
+	return deps, nil
+}
+
+func labelToPath(label string) string {
+	pkg, name, found := strings.Cut(strings.TrimPrefix(label, "//"), ":")
+	if !found {
+		return pkg
+	}
+	return filepath.Join(pkg, name)
+}
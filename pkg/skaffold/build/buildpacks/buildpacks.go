@@ -0,0 +1,68 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildpacks
+
+import (
+	"context"
+	"io"
+	"os/exec"
+
+	"github.com/pkg/errors"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+// Build runs `pack build` for a against workspace, tagging the result tag,
+// then pushes it when the environment asks for it.
+func Build(ctx context.Context, out io.Writer, workspace string, a *latest.BuildpackArtifact, tag string, env build.Environment) (string, error) {
+	args := []string{"build", tag, "--path", workspace}
+	if a.Builder != "" {
+		args = append(args, "--builder", a.Builder)
+	}
+	for _, bp := range a.Buildpacks {
+		args = append(args, "--buildpack", bp)
+	}
+	if platform, ok := build.PlatformFromContext(ctx); ok {
+		args = append(args, "--platform", platform)
+	}
+	args = append(args, build.CacheFlagsFromContext(ctx)...)
+
+	cmd := exec.CommandContext(ctx, "pack", args...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrap(err, "running pack build")
+	}
+
+	if env.PushImages {
+		return env.LocalDocker.Push(ctx, out, tag)
+	}
+
+	insp, _, err := env.LocalDocker.ImageInspectWithRaw(ctx, tag)
+	if err != nil {
+		return "", errors.Wrap(err, "inspecting built image")
+	}
+	return insp.ID, nil
+}
+
+// GetDependencies returns the paths a's build depends on. Buildpacks builds
+// consume the whole workspace, since the builder itself decides what it
+// reads from it.
+func GetDependencies(ctx context.Context, workspace string, a *latest.BuildpackArtifact) ([]string, error) {
+	return []string{workspace}, nil
+}
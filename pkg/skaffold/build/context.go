@@ -0,0 +1,59 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import "context"
+
+// These context keys carry per-build request, read by whichever
+// ArtifactBackend ends up handling the artifact, so a Builder can attach
+// them once without knowing which backend (in-process or self-registered in
+// another package) will read them back.
+
+type platformContextKey struct{}
+
+// WithPlatform attaches the target platform to ctx for an ArtifactBackend to
+// pick up and pass through as --platform, -Djib.from.platforms, etc.
+func WithPlatform(ctx context.Context, platform string) context.Context {
+	return context.WithValue(ctx, platformContextKey{}, platform)
+}
+
+// PlatformFromContext returns the target platform for the current build, if
+// a multi-platform build attached one.
+func PlatformFromContext(ctx context.Context) (string, bool) {
+	platform, ok := ctx.Value(platformContextKey{}).(string)
+	return platform, ok
+}
+
+type cacheFlagsContextKey struct{}
+
+// WithCacheFlags attaches the extra docker/pack CLI flags an ArtifactBackend
+// needs to append to its build invocation for cache import/export to take
+// effect.
+func WithCacheFlags(ctx context.Context, flags []string) context.Context {
+	if len(flags) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, cacheFlagsContextKey{}, flags)
+}
+
+// CacheFlagsFromContext returns the --cache-from/--cache-to (or legacy
+// --cache-from) flags an ArtifactBackend should append to its daemon build
+// call, if this build has cache import/export configured.
+func CacheFlagsFromContext(ctx context.Context) []string {
+	flags, _ := ctx.Value(cacheFlagsContextKey{}).([]string)
+	return flags
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package custom builds CustomArtifacts by shelling out to a user-provided
+// build script. It registers itself with the global build.RegisterBackend
+// registry so that build/local doesn't need to know this package exists.
+package custom
+
+import (
+	"context"
+	"io"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+func init() {
+	build.RegisterBackend(Backend{})
+}
+
+// Backend is the build.ArtifactBackend for CustomArtifact.
+type Backend struct{}
+
+func (Backend) Name() string { return "custom" }
+
+func (Backend) Matches(a *latest.Artifact) bool { return a.CustomArtifact != nil }
+
+func (Backend) Build(ctx context.Context, out io.Writer, a *latest.Artifact, tag string) (string, error) {
+	env := build.EnvironmentFromContext(ctx)
+	return Build(ctx, out, a.Workspace, a.CustomArtifact, tag, env)
+}
+
+func (Backend) Dependencies(ctx context.Context, a *latest.Artifact) ([]string, error) {
+	env := build.EnvironmentFromContext(ctx)
+	return GetDependencies(ctx, a.Workspace, a.CustomArtifact, env.InsecureRegistries)
+}
+
+func (Backend) SyncMap(ctx context.Context, a *latest.Artifact) (map[string][]string, error) {
+	return nil, build.ErrSyncMapNotSupported{}
+}
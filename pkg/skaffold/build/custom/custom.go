@@ -0,0 +1,85 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package custom
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+// Build runs a's build command with IMAGE/PUSH_IMAGE/BUILD_CONTEXT set in
+// its environment. The script itself is responsible for building -- and,
+// when PUSH_IMAGE is true, pushing -- the image named by IMAGE.
+func Build(ctx context.Context, out io.Writer, workspace string, a *latest.CustomArtifact, tag string, env build.Environment) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", a.BuildCommand)
+	cmd.Dir = workspace
+	cmd.Env = append(os.Environ(),
+		"IMAGE="+tag,
+		fmt.Sprintf("PUSH_IMAGE=%t", env.PushImages),
+		"BUILD_CONTEXT="+workspace,
+	)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrap(err, "running custom build command")
+	}
+
+	if env.PushImages {
+		insp, _, err := env.LocalDocker.ImageInspectWithRaw(ctx, tag)
+		if err != nil {
+			return "", errors.Wrap(err, "inspecting pushed image")
+		}
+		for _, repoDigest := range insp.RepoDigests {
+			return repoDigest, nil
+		}
+		return "", errors.Errorf("custom build command for %q set PUSH_IMAGE=true but left no repo digest", tag)
+	}
+
+	insp, _, err := env.LocalDocker.ImageInspectWithRaw(ctx, tag)
+	if err != nil {
+		return "", errors.Wrap(err, "inspecting built image")
+	}
+	return insp.ID, nil
+}
+
+// GetDependencies returns the paths a's build command depends on. With no
+// explicit Dependencies.Paths, the whole workspace is treated as a
+// dependency, since an opaque build command could read anything in it.
+func GetDependencies(ctx context.Context, workspace string, a *latest.CustomArtifact, insecureRegistries map[string]bool) ([]string, error) {
+	if a.Dependencies == nil || len(a.Dependencies.Paths) == 0 {
+		return []string{workspace}, nil
+	}
+
+	var deps []string
+	for _, pattern := range a.Dependencies.Paths {
+		matches, err := filepath.Glob(filepath.Join(workspace, pattern))
+		if err != nil {
+			return nil, errors.Wrapf(err, "expanding dependency pattern %q", pattern)
+		}
+		deps = append(deps, matches...)
+	}
+	return deps, nil
+}
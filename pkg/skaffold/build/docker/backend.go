@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package docker builds DockerArtifacts against the local docker daemon.
+// It registers itself with the global build.RegisterBackend registry so
+// that build/local doesn't need to know this package exists.
+package docker
+
+import (
+	"context"
+	"io"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	skaffolddocker "github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+func init() {
+	build.RegisterBackend(Backend{})
+}
+
+// Backend is the build.ArtifactBackend for DockerArtifact.
+type Backend struct{}
+
+func (Backend) Name() string { return "docker" }
+
+func (Backend) Matches(a *latest.Artifact) bool { return a.DockerArtifact != nil }
+
+func (Backend) Build(ctx context.Context, out io.Writer, a *latest.Artifact, tag string) (string, error) {
+	env := build.EnvironmentFromContext(ctx)
+	opts := skaffolddocker.BuildOptions{
+		Tag:                tag,
+		InsecureRegistries: env.InsecureRegistries,
+		ExtraFlags:         build.CacheFlagsFromContext(ctx),
+	}
+	if platform, ok := build.PlatformFromContext(ctx); ok {
+		opts.Platform = platform
+	}
+
+	if _, err := env.LocalDocker.Build(ctx, out, a.Workspace, a.DockerArtifact.DockerfilePath, a.DockerArtifact.BuildArgs, opts); err != nil {
+		return "", err
+	}
+
+	if env.PushImages {
+		return env.LocalDocker.Push(ctx, out, tag)
+	}
+
+	insp, _, err := env.LocalDocker.ImageInspectWithRaw(ctx, tag)
+	if err != nil {
+		return "", err
+	}
+	return insp.ID, nil
+}
+
+func (Backend) Dependencies(ctx context.Context, a *latest.Artifact) ([]string, error) {
+	env := build.EnvironmentFromContext(ctx)
+	return skaffolddocker.GetDependencies(ctx, a.Workspace, a.DockerArtifact.DockerfilePath, a.DockerArtifact.BuildArgs, env.InsecureRegistries)
+}
+
+func (Backend) SyncMap(ctx context.Context, a *latest.Artifact) (map[string][]string, error) {
+	env := build.EnvironmentFromContext(ctx)
+	return skaffolddocker.SyncMap(ctx, a.Workspace, a.DockerArtifact.DockerfilePath, a.DockerArtifact.BuildArgs, env.InsecureRegistries)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+)
+
+// Environment is the per-instance state (daemon client, registry config, ...)
+// that a self-registering ArtifactBackend needs but can't hold itself, since
+// a single process-wide registry entry is shared across every Builder. The
+// owning Builder attaches it to each build's context with WithEnvironment
+// instead of constructing backends itself.
+type Environment struct {
+	LocalDocker        docker.LocalDaemon
+	InsecureRegistries map[string]bool
+	PushImages         bool
+	KubeContext        string
+}
+
+type environmentContextKey struct{}
+
+// WithEnvironment attaches env to ctx for a registered ArtifactBackend to
+// read back via EnvironmentFromContext.
+func WithEnvironment(ctx context.Context, env Environment) context.Context {
+	return context.WithValue(ctx, environmentContextKey{}, env)
+}
+
+// EnvironmentFromContext returns the Environment attached by WithEnvironment,
+// or the zero value if the builder never attached one.
+func EnvironmentFromContext(ctx context.Context) Environment {
+	env, _ := ctx.Value(environmentContextKey{}).(Environment)
+	return env
+}
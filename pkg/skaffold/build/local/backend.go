@@ -0,0 +1,58 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"context"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+
+	// Each of these packages registers its own build.ArtifactBackend in an
+	// init() function, so a third party can add a new artifact type by
+	// importing build.RegisterBackend from their own package, without
+	// touching this file.
+	_ "github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/bazel"
+	_ "github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/buildpacks"
+	_ "github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/custom"
+	_ "github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/docker"
+	_ "github.com/GoogleContainerTools/skaffold/pkg/skaffold/jib"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+// environment captures this Builder's per-instance state (daemon client,
+// registry config, ...) for the registered ArtifactBackend to read back via
+// build.EnvironmentFromContext, since the global registry can't close over
+// any one Builder directly.
+func (b *Builder) environment() build.Environment {
+	return build.Environment{
+		LocalDocker:        b.localDocker,
+		InsecureRegistries: b.insecureRegistries,
+		PushImages:         b.pushImages,
+		KubeContext:        b.kubeContext,
+	}
+}
+
+// withEnvironment attaches this Builder's environment to ctx for whichever
+// backend ends up handling the artifact.
+func (b *Builder) withEnvironment(ctx context.Context) context.Context {
+	return build.WithEnvironment(ctx, b.environment())
+}
+
+// backendFor resolves the ArtifactBackend registered for an artifact's type.
+func (b *Builder) backendFor(artifact *latest.Artifact) (build.ArtifactBackend, error) {
+	return build.BackendFor(artifact)
+}
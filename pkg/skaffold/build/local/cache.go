@@ -0,0 +1,130 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+)
+
+// Cache export modes for CacheConfig.Mode.
+const (
+	CacheModeInline = "inline"
+	CacheModeMax    = "max"
+)
+
+// CacheConfig controls where the local builder imports and exports BuildKit
+// layer cache from/to, so cold CI runners can reuse a warm cache that
+// already lives in a registry instead of paying full build cost every time.
+type CacheConfig struct {
+	ImportFrom  []string
+	ExportTo    []string
+	Mode        string
+	UseBuildKit bool
+}
+
+// withCacheFlagsForBuild resolves and attaches this builder's cache flags to
+// ctx, preferring BuildKit's native cache-from/cache-to and falling back to
+// pre-pulling ImportFrom refs for classic `--cache-from` when BuildKit isn't
+// available. Self-registered ArtifactBackends read these back with
+// build.CacheFlagsFromContext.
+func (b *Builder) withCacheFlagsForBuild(ctx context.Context) context.Context {
+	if len(b.cacheConfig.ImportFrom) == 0 && len(b.cacheConfig.ExportTo) == 0 {
+		return ctx
+	}
+
+	if b.buildKitEnabled() {
+		return build.WithCacheFlags(ctx, b.cacheConfig.buildKitCacheFlags())
+	}
+	return build.WithCacheFlags(ctx, b.legacyCacheFromArgs(ctx))
+}
+
+func (b *Builder) buildKitEnabled() bool {
+	return b.cacheConfig.UseBuildKit || os.Getenv("DOCKER_BUILDKIT") == "1"
+}
+
+// buildKitCacheFlags returns the --cache-from/--cache-to flags to pass to a
+// BuildKit-enabled `docker build`.
+func (c CacheConfig) buildKitCacheFlags() []string {
+	var flags []string
+	for _, ref := range c.ImportFrom {
+		flags = append(flags, "--cache-from", fmt.Sprintf("type=registry,ref=%s", ref))
+	}
+
+	mode := c.Mode
+	if mode == "" {
+		mode = CacheModeInline
+	}
+	for _, ref := range c.ExportTo {
+		if mode == CacheModeMax {
+			flags = append(flags, "--cache-to", fmt.Sprintf("type=registry,ref=%s,mode=max", ref))
+		} else {
+			flags = append(flags, "--cache-to", fmt.Sprintf("type=inline,ref=%s", ref))
+		}
+	}
+	return flags
+}
+
+// legacyCacheFromArgs pulls each ImportFrom ref so it can be used as a
+// classic `--cache-from`, for daemons that don't have BuildKit enabled.
+func (b *Builder) legacyCacheFromArgs(ctx context.Context) []string {
+	var flags []string
+	for _, ref := range b.cacheConfig.ImportFrom {
+		if err := b.localDocker.Pull(ctx, ioutil.Discard, ref); err != nil {
+			logrus.Warnf("unable to pull cache image %s, continuing without it: %v", ref, err)
+			continue
+		}
+		flags = append(flags, "--cache-from", ref)
+	}
+	return flags
+}
+
+// persistCacheManifest records the cache refs exported by this build so
+// `skaffold dev`'s iterative loop can find and reuse them between processes.
+func (b *Builder) persistCacheManifest() error {
+	refs := b.cacheConfig.ExportTo
+	if len(refs) == 0 {
+		return nil
+	}
+
+	path, err := cacheManifestPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "creating cache manifest directory")
+	}
+	return ioutil.WriteFile(path, []byte(strings.Join(refs, "\n")+"\n"), 0644)
+}
+
+func cacheManifestPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "resolving cache directory")
+	}
+	return filepath.Join(dir, "skaffold", "build-cache-refs"), nil
+}
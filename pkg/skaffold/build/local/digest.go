@@ -0,0 +1,172 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+// Values for the --digest-source flag.
+const (
+	DigestSourceInspect  = "inspect"
+	DigestSourceRegistry = "registry"
+	DigestSourceNone     = "none"
+)
+
+// withDigests enriches each built artifact with a build.Result's worth of
+// extra metadata (digest, image ID, size, platform, ...), so downstream
+// deploy/render stages and the event API get a stable content-addressed
+// reference even when nothing was pushed to a registry.
+func (b *Builder) withDigests(ctx context.Context, artifacts []*latest.Artifact, built []build.Artifact) []build.Artifact {
+	if b.digestSource == DigestSourceNone {
+		return built
+	}
+
+	byName := make(map[string]*latest.Artifact, len(artifacts))
+	for _, a := range artifacts {
+		byName[a.ImageName] = a
+	}
+
+	for i, artifact := range built {
+		a, ok := byName[artifact.ImageName]
+		if !ok {
+			continue
+		}
+
+		result, err := b.resultForArtifact(ctx, a, artifact.Tag)
+		if err != nil {
+			logrus.Warnf("unable to compute digest for %s: %v", artifact.ImageName, err)
+			continue
+		}
+
+		built[i].ImageID = result.ImageID
+		built[i].Digest = result.Digest
+		built[i].RepoDigest = result.RepoDigest
+		built[i].ConfigDigest = result.ConfigDigest
+		built[i].Size = result.Size
+		built[i].Platform = result.Platform
+		built[i].Timestamp = result.Timestamp
+	}
+	return built
+}
+
+// resultForArtifact builds a build.Result for a successfully built artifact.
+// taggedRef is whatever buildArtifact returned: either a registry reference
+// with an embedded digest (tag@sha256:...) or a plain local tag.
+func (b *Builder) resultForArtifact(ctx context.Context, artifact *latest.Artifact, taggedRef string) (build.Result, error) {
+	result := build.Result{Tag: taggedRef}
+
+	if at := strings.Index(taggedRef, "@sha256:"); at != -1 {
+		// Already pushed: the digest came straight from the registry.
+		result.Digest = taggedRef[at+1:]
+		result.RepoDigest = taggedRef
+		return result, nil
+	}
+
+	if b.digestSource != DigestSourceInspect {
+		// DigestSourceRegistry with nothing pushed has nothing to report.
+		return result, nil
+	}
+
+	insp, _, err := b.localDocker.ImageInspectWithRaw(ctx, taggedRef)
+	if err != nil {
+		return build.Result{}, errors.Wrap(err, "inspecting built image")
+	}
+
+	// ImageConfig returns the real v1 config blob -- the same bytes a
+	// registry push stores as the manifest's config descriptor -- so the
+	// descriptor's digest and size are self-consistent. ImageInspectWithRaw's
+	// raw bytes are a different document (the client-facing inspect
+	// response) and can't be used for either value.
+	configDigest, configBytes, err := b.localDocker.ImageConfig(ctx, taggedRef)
+	if err != nil {
+		return build.Result{}, errors.Wrap(err, "reading image config")
+	}
+
+	manifestJSON, err := canonicalManifest(configDigest, int64(len(configBytes)))
+	if err != nil {
+		return build.Result{}, errors.Wrap(err, "building canonical manifest")
+	}
+	manifestDigest := canonicalDigest(manifestJSON)
+
+	result.ImageID = configDigest
+	result.ConfigDigest = configDigest
+	result.Digest = manifestDigest
+	result.RepoDigest = taggedRef + "@" + manifestDigest
+	result.Size = insp.Size
+	result.Platform = fmt.Sprintf("%s/%s", insp.Os, insp.Architecture)
+	if ts, err := time.Parse(time.RFC3339Nano, insp.Created); err == nil {
+		result.Timestamp = ts
+	}
+	return result, nil
+}
+
+// Media types for the minimal Docker Image Manifest, Schema 2 built by
+// canonicalManifest.
+const (
+	mediaTypeManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeConfig   = "application/vnd.docker.container.image.v1+json"
+)
+
+// dockerV2Manifest is the subset of the Docker Image Manifest, Schema 2 that
+// canonicalManifest fills in: the config descriptor only. Layer descriptors
+// are omitted, so its digest is stable for a given config but is not the
+// digest a registry assigns to the pushed image.
+type dockerV2Manifest struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	MediaType     string             `json:"mediaType"`
+	Config        dockerV2Descriptor `json:"config"`
+}
+
+type dockerV2Descriptor struct {
+	MediaType string `json:"mediaType"`
+	Size      int64  `json:"size"`
+	Digest    string `json:"digest"`
+}
+
+// canonicalManifest builds the canonical JSON bytes of a config-only manifest
+// wrapping configDigest; resultForArtifact uses its digest as the image's
+// locally computed digest.
+func canonicalManifest(configDigest string, configSize int64) ([]byte, error) {
+	return json.Marshal(dockerV2Manifest{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeManifest,
+		Config: dockerV2Descriptor{
+			MediaType: mediaTypeConfig,
+			Size:      configSize,
+			Digest:    configDigest,
+		},
+	})
+}
+
+// canonicalDigest returns the sha256 digest of data in "sha256:<hex>" form.
+func canonicalDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCanonicalDigestFormat(t *testing.T) {
+	digest := canonicalDigest([]byte("hello"))
+	if !strings.HasPrefix(digest, "sha256:") {
+		t.Fatalf("got %q, want a sha256: prefixed digest", digest)
+	}
+	if len(digest) != len("sha256:")+64 {
+		t.Errorf("got digest of length %d, want %d", len(digest), len("sha256:")+64)
+	}
+}
+
+func TestCanonicalDigestDeterministic(t *testing.T) {
+	if canonicalDigest([]byte("same bytes")) != canonicalDigest([]byte("same bytes")) {
+		t.Error("canonicalDigest isn't deterministic for identical input")
+	}
+}
+
+func TestCanonicalManifestDigestChangesWithConfigDigest(t *testing.T) {
+	m1, err := canonicalManifest("sha256:"+strings.Repeat("a", 64), 42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m2, err := canonicalManifest("sha256:"+strings.Repeat("b", 64), 42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if canonicalDigest(m1) == canonicalDigest(m2) {
+		t.Error("manifests wrapping different config digests produced the same digest")
+	}
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import "github.com/spf13/pflag"
+
+// LocalConcurrency backs the --local-concurrency flag: the number of
+// artifacts the local builder builds at once. It defaults to
+// defaultLocalConcurrency, matching the pre-existing sequential behavior;
+// NewBuilder also treats 0 as defaultLocalConcurrency.
+var LocalConcurrency int
+
+// DigestSource backs the --digest-source flag: where each built artifact's
+// digest comes from. One of DigestSourceInspect (the default),
+// DigestSourceRegistry, or DigestSourceNone.
+var DigestSource string
+
+// AddFlags registers the local builder's command-line flags. cmd/skaffold/app
+// calls this alongside the other builders' AddFlags during startup.
+func AddFlags(flags *pflag.FlagSet) {
+	flags.IntVar(&LocalConcurrency, "local-concurrency", defaultLocalConcurrency, "Number of artifacts to build concurrently with the local builder")
+	flags.StringVar(&DigestSource, "digest-source", DigestSourceInspect, "Set to 'inspect' to compute the digest locally from the image's config when it wasn't pushed, 'registry' to report only the digest returned by a push, or 'none' to skip digest resolution")
+}
@@ -18,20 +18,15 @@ package local
 
 import (
 	"context"
-	"fmt"
 	"io"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/bazel"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/buildpacks"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/custom"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/color"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/jib"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 )
@@ -44,17 +39,53 @@ func (b *Builder) Build(ctx context.Context, out io.Writer, tags tag.ImageTags,
 	}
 	defer b.localDocker.Close()
 
-	// TODO(dgageot): parallel builds
-	return build.InSequence(ctx, out, tags, artifacts, b.buildArtifact)
+	var (
+		built []build.Artifact
+		err   error
+	)
+	if b.concurrency == 1 {
+		built, err = build.InSequence(ctx, out, tags, artifacts, b.buildArtifact)
+	} else {
+		built, err = b.buildInParallel(ctx, out, tags, artifacts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.persistCacheManifest(); err != nil {
+		logrus.Warnf("unable to persist build cache manifest: %v", err)
+	}
+
+	return b.withDigests(ctx, artifacts, built), nil
 }
 
 func (b *Builder) buildArtifact(ctx context.Context, out io.Writer, artifact *latest.Artifact, tag string) (string, error) {
-	digestOrImageID, err := b.runBuildForArtifact(ctx, out, artifact, tag)
+	if b.pushImages && artifact.Reproducible != "" {
+		// Every backend pushes as part of its own Build call, so by the time
+		// buildArtifact would apply the reproducible rewrite below, the
+		// (non-reproducible) image has already been pushed -- regardless of
+		// whether this builder type otherwise knows how to rewrite images.
+		return "", ErrReproducibleNotSupportedWhenPushing{ImageName: artifact.ImageName}
+	}
+
+	digestOrImageID, err := b.buildMultiPlatform(ctx, out, artifact, tag)
 	if err != nil {
 		return "", errors.Wrap(err, "build artifact")
 	}
 
 	if b.pushImages {
+		if len(artifact.Platforms) > 0 {
+			// A multi-platform build already assembled and pushed a manifest
+			// list, so digestOrImageID is already a complete tag@digest ref.
+			return digestOrImageID, nil
+		}
+
+		// Backends return the pushed image's repo digest (name@sha256:...);
+		// keep only the digest so it can be appended to tag below.
+		if at := strings.Index(digestOrImageID, "@"); at != -1 {
+			digestOrImageID = digestOrImageID[at+1:]
+		}
+
 		// only track images for pruning when building with docker
 		// if we're pushing a bazel image, it was built directly to the registry
 		if artifact.DockerArtifact != nil {
@@ -63,7 +94,7 @@ func (b *Builder) buildArtifact(ctx context.Context, out io.Writer, artifact *la
 				logrus.Warnf("unable to inspect image: built images may not be cleaned up correctly by skaffold")
 			}
 			if imageID != "" {
-				b.builtImages = append(b.builtImages, imageID)
+				b.trackBuiltImage(imageID)
 			}
 		}
 		digest := digestOrImageID
@@ -71,74 +102,44 @@ func (b *Builder) buildArtifact(ctx context.Context, out io.Writer, artifact *la
 	}
 
 	imageID := digestOrImageID
-	b.builtImages = append(b.builtImages, imageID)
-	return b.localDocker.TagWithImageID(ctx, tag, imageID)
-}
-
-func (b *Builder) runBuildForArtifact(ctx context.Context, out io.Writer, artifact *latest.Artifact, tag string) (string, error) {
-	switch {
-	case artifact.DockerArtifact != nil:
-		return b.buildDocker(ctx, out, artifact, tag)
-
-	case artifact.BazelArtifact != nil:
-		return b.buildBazel(ctx, out, artifact, tag)
-
-	case artifact.JibArtifact != nil:
-		return b.buildJib(ctx, out, artifact, tag)
-
-	case artifact.CustomArtifact != nil:
-		return b.buildCustom(ctx, out, artifact, tag)
+	if artifact.Reproducible != "" {
+		builder, supported := reproducibleSupported(artifact)
+		if !supported {
+			return "", ErrReproducibleNotSupportedForBuilder{Builder: builder}
+		}
 
-	case artifact.BuildpackArtifact != nil:
-		return b.buildBuildpack(ctx, out, artifact, tag)
+		ts, err := b.resolveReproducibleTimestamp(ctx, artifact, artifact.Reproducible)
+		if err != nil {
+			return "", err
+		}
 
-	default:
-		return "", fmt.Errorf("undefined artifact type: %+v", artifact.ArtifactType)
+		rewritten, err := b.localDocker.RewriteImageTimestamps(ctx, imageID, ts)
+		if err != nil {
+			return "", errors.Wrap(err, "rewriting image timestamps for reproducible build")
+		}
+		imageID = rewritten
 	}
+
+	b.trackBuiltImage(imageID)
+	return b.localDocker.TagWithImageID(ctx, tag, imageID)
 }
 
-func (b *Builder) buildJib(ctx context.Context, out io.Writer, artifact *latest.Artifact, tag string) (string, error) {
-	t, err := jib.DeterminePluginType(artifact.Workspace, artifact.JibArtifact)
+func (b *Builder) runBuildForArtifact(ctx context.Context, out io.Writer, artifact *latest.Artifact, tag string) (string, error) {
+	backend, err := b.backendFor(artifact)
 	if err != nil {
 		return "", err
 	}
-
-	switch t {
-	case jib.JibMaven:
-		return b.buildJibMaven(ctx, out, artifact.Workspace, artifact.JibArtifact, tag)
-	case jib.JibGradle:
-		return b.buildJibGradle(ctx, out, artifact.Workspace, artifact.JibArtifact, tag)
-	default:
-		return "", errors.Errorf("Unable to determine Jib builder type for %s", artifact.Workspace)
-	}
+	ctx = b.withEnvironment(b.withCacheFlagsForBuild(ctx))
+	return backend.Build(ctx, out, artifact, tag)
 }
 
 func (b *Builder) DependenciesForArtifact(ctx context.Context, a *latest.Artifact) ([]string, error) {
-	var (
-		paths []string
-		err   error
-	)
-
-	switch {
-	case a.DockerArtifact != nil:
-		paths, err = docker.GetDependencies(ctx, a.Workspace, a.DockerArtifact.DockerfilePath, a.DockerArtifact.BuildArgs, b.insecureRegistries)
-
-	case a.BazelArtifact != nil:
-		paths, err = bazel.GetDependencies(ctx, a.Workspace, a.BazelArtifact)
-
-	case a.JibArtifact != nil:
-		paths, err = jib.GetDependencies(ctx, a.Workspace, a.JibArtifact)
-
-	case a.CustomArtifact != nil:
-		paths, err = custom.GetDependencies(ctx, a.Workspace, a.CustomArtifact, b.insecureRegistries)
-
-	case a.BuildpackArtifact != nil:
-		paths, err = buildpacks.GetDependencies(ctx, a.Workspace, a.BuildpackArtifact)
-
-	default:
-		return nil, fmt.Errorf("undefined artifact type: %+v", a.ArtifactType)
+	backend, err := b.backendFor(a)
+	if err != nil {
+		return nil, err
 	}
 
+	paths, err := backend.Dependencies(b.withEnvironment(ctx), a)
 	if err != nil {
 		return nil, err
 	}
@@ -155,9 +156,9 @@ func (b *Builder) getImageIDForTag(ctx context.Context, tag string) (string, err
 }
 
 func (b *Builder) SyncMap(ctx context.Context, a *latest.Artifact) (map[string][]string, error) {
-	if a.DockerArtifact == nil {
-		return nil, build.ErrSyncMapNotSupported{}
+	backend, err := b.backendFor(a)
+	if err != nil {
+		return nil, err
 	}
-
-	return docker.SyncMap(ctx, a.Workspace, a.DockerArtifact.DockerfilePath, a.DockerArtifact.BuildArgs, b.insecureRegistries)
+	return backend.SyncMap(b.withEnvironment(ctx), a)
 }
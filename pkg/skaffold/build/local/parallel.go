@@ -0,0 +1,157 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+// buildInParallel builds the given artifacts across a bounded pool of
+// workers, respecting each artifact's Dependencies so an artifact that
+// requires another artifact's output still builds after it, even though
+// builds are otherwise unordered.
+func (b *Builder) buildInParallel(ctx context.Context, out io.Writer, tags tag.ImageTags, artifacts []*latest.Artifact) ([]build.Artifact, error) {
+	byName := make(map[string]int, len(artifacts))
+	for i, a := range artifacts {
+		byName[a.ImageName] = i
+	}
+
+	results := make([]build.Artifact, len(artifacts))
+
+	// waitForDeps blocks job i on its dependencies' completion before it's
+	// allowed to claim a worker slot. It must run *before* a worker slot is
+	// acquired: if it ran after, a dependent artifact could hold a slot
+	// while waiting on a dependency that's never scheduled because no slot
+	// is free for it, deadlocking the whole pool.
+	waitForDeps := func(ctx context.Context, i int, isDone func(j int) <-chan struct{}) error {
+		artifact := artifacts[i]
+		for _, dep := range artifact.Dependencies {
+			depIdx, ok := byName[dep.ImageName]
+			if !ok {
+				continue
+			}
+
+			select {
+			case <-isDone(depIdx):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			if results[depIdx].Tag == "" {
+				return errors.Errorf("dependency %q for artifact %q failed to build", dep.ImageName, artifact.ImageName)
+			}
+		}
+		return nil
+	}
+
+	err := runOrderedConcurrent(ctx, out, len(artifacts), b.concurrency, waitForDeps, func(ctx context.Context, i int, w io.Writer) error {
+		artifact := artifacts[i]
+
+		tagged, err := b.buildArtifact(ctx, w, artifact, tags[artifact.ImageName])
+		if err != nil {
+			return errors.Wrapf(err, "building [%s]", artifact.ImageName)
+		}
+
+		results[i] = build.Artifact{
+			ImageName: artifact.ImageName,
+			Tag:       tagged,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// runOrderedConcurrent runs n jobs with at most `concurrency` (clamped to a
+// minimum of 1) running at once. Each job writes to its own buffer, which is
+// flushed to out, in job-index order, as soon as that job finishes — so
+// concurrent builds never interleave their output, and concurrency=1
+// reproduces a strictly sequential, in-order log.
+//
+// waitForDeps, if non-nil, is called for each job i before that job claims a
+// worker slot; isDone(j) reports when job j has finished. Running this
+// *before* the worker slot is claimed is what lets a bounded pool host
+// dependency chains safely: a job waiting on a dependency never occupies a
+// slot its dependency needs in order to run.
+func runOrderedConcurrent(ctx context.Context, out io.Writer, n, concurrency int, waitForDeps func(ctx context.Context, i int, isDone func(j int) <-chan struct{}) error, run func(ctx context.Context, i int, w io.Writer) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	buffers := make([]bytes.Buffer, n)
+	done := make([]chan struct{}, n)
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	isDone := func(j int) <-chan struct{} { return done[j] }
+
+	// Every job closes its own done[i] in every code path below, whether it
+	// ran, failed, was cancelled while waiting on a dependency, or was
+	// cancelled before it started — so this loop always drains, in
+	// submission order, without racing against ctx.Done().
+	flushed := make(chan struct{})
+	go func() {
+		for i := 0; i < n; i++ {
+			<-done[i]
+			out.Write(buffers[i].Bytes())
+		}
+		close(flushed)
+	}()
+
+	sem := make(chan struct{}, concurrency)
+	g, ctx := errgroup.WithContext(ctx)
+
+	for i := 0; i < n; i++ {
+		i := i
+
+		g.Go(func() error {
+			defer close(done[i])
+
+			if waitForDeps != nil {
+				if err := waitForDeps(ctx, i, isDone); err != nil {
+					return err
+				}
+			}
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			defer func() { <-sem }()
+
+			return run(ctx, i, &buffers[i])
+		})
+	}
+
+	err := g.Wait()
+	<-flushed
+	return err
+}
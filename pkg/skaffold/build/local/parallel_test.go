@@ -0,0 +1,111 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRunOrderedConcurrentDeterministicOutput(t *testing.T) {
+	const n = 5
+
+	var out bytes.Buffer
+	err := runOrderedConcurrent(context.Background(), &out, n, 3, nil, func(ctx context.Context, i int, w io.Writer) error {
+		// Finish in reverse order to prove the flush order doesn't depend on
+		// completion order, only on submission (index) order.
+		time.Sleep(time.Duration(n-i) * time.Millisecond)
+		fmt.Fprintf(w, "artifact-%d\n", i)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "artifact-0\nartifact-1\nartifact-2\nartifact-3\nartifact-4\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestRunOrderedConcurrentConcurrencyOneMatchesSequential(t *testing.T) {
+	const n = 4
+
+	run := func(w io.Writer, i int) error {
+		fmt.Fprintf(w, "artifact-%d\n", i)
+		return nil
+	}
+
+	var sequential bytes.Buffer
+	for i := 0; i < n; i++ {
+		if err := run(&sequential, i); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var parallel bytes.Buffer
+	err := runOrderedConcurrent(context.Background(), &parallel, n, 1, nil, func(ctx context.Context, i int, w io.Writer) error {
+		return run(w, i)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if parallel.String() != sequential.String() {
+		t.Errorf("concurrency=1 output %q does not match sequential output %q", parallel.String(), sequential.String())
+	}
+}
+
+// TestRunOrderedConcurrentDependencyChainDoesNotDeadlock exercises a 3-job
+// dependency chain (0 depends on 1, 1 depends on 2) under a worker pool
+// smaller than the chain length. If a job could claim its worker slot before
+// waiting on its dependency, jobs 0 and 1 could occupy both slots while
+// blocked, leaving none for job 2, which they wait on, deadlocking the pool.
+func TestRunOrderedConcurrentDependencyChainDoesNotDeadlock(t *testing.T) {
+	const n = 3
+	dependsOn := map[int]int{0: 1, 1: 2}
+
+	for attempt := 0; attempt < 20; attempt++ {
+		var out bytes.Buffer
+		waitForDeps := func(ctx context.Context, i int, isDone func(j int) <-chan struct{}) error {
+			dep, ok := dependsOn[i]
+			if !ok {
+				return nil
+			}
+			select {
+			case <-isDone(dep):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := runOrderedConcurrent(ctx, &out, n, 2, waitForDeps, func(ctx context.Context, i int, w io.Writer) error {
+			fmt.Fprintf(w, "artifact-%d\n", i)
+			return nil
+		})
+		cancel()
+		if err != nil {
+			t.Fatalf("attempt %d: unexpected error (possible deadlock): %v", attempt, err)
+		}
+	}
+}
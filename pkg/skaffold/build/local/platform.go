@@ -0,0 +1,201 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+// ErrEmulationNotAvailable is returned when building for a foreign platform
+// requires binfmt_misc/qemu emulation that isn't registered with the kernel.
+type ErrEmulationNotAvailable struct {
+	Platform string
+}
+
+func (e ErrEmulationNotAvailable) Error() string {
+	return fmt.Sprintf("building for platform %s requires binfmt_misc/qemu emulation, but no handler is registered; install qemu-user-static (e.g. `docker run --privileged --rm tonistiigi/binfmt --install all`) and retry", e.Platform)
+}
+
+// buildMultiPlatform runs runBuildForArtifact once per platform declared on
+// the artifact. When pushing, it assembles the resulting images into a single
+// manifest list; otherwise it returns the first platform's tag. Artifacts
+// without Platforms set build exactly as before.
+func (b *Builder) buildMultiPlatform(ctx context.Context, out io.Writer, artifact *latest.Artifact, tag string) (string, error) {
+	platforms := artifact.Platforms
+	if len(platforms) == 0 {
+		return b.runBuildForArtifact(ctx, out, artifact, tag)
+	}
+
+	for _, platform := range platforms {
+		if err := ensureEmulation(platform); err != nil {
+			return "", err
+		}
+	}
+
+	platformTags := make([]string, len(platforms))
+	builtImages := make([]string, len(platforms))
+	buffers := make([]bytes.Buffer, len(platforms))
+
+	g, ctx := errgroup.WithContext(ctx)
+	for i, platform := range platforms {
+		i, platform := i, platform
+
+		g.Go(func() error {
+			platformTag := fmt.Sprintf("%s-%s", tag, sanitizePlatform(platform))
+
+			digestOrImageID, err := b.runBuildForArtifact(build.WithPlatform(ctx, platform), &buffers[i], artifact, platformTag)
+			if err != nil {
+				return errors.Wrapf(err, "building %s for platform %s", artifact.ImageName, platform)
+			}
+
+			platformTags[i] = platformTag
+			builtImages[i] = digestOrImageID
+			return nil
+		})
+	}
+	err := g.Wait()
+	for i := range buffers {
+		out.Write(buffers[i].Bytes())
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if artifact.DockerArtifact != nil {
+		// Every platform's build still materializes a local daemon image,
+		// whether or not the final manifest list gets pushed, so all of them
+		// need to be tracked for pruning. Per-platform images built by the
+		// bazel/jib/buildpacks/custom backends are not tracked.
+		b.trackBuiltImage(builtImages...)
+	}
+
+	if !b.pushImages {
+		// Without a push there's no daemon-side multi-arch index to assemble;
+		// the first platform's image is the best local stand-in.
+		return platformTags[0], nil
+	}
+
+	return b.assembleManifestList(ctx, tag, platformTags)
+}
+
+func sanitizePlatform(platform string) string {
+	return strings.ReplaceAll(platform, "/", "-")
+}
+
+// assembleManifestList creates and pushes a manifest list referencing each
+// per-platform image, via the local docker CLI's `manifest` subcommands, and
+// returns the index's own tag@digest reference.
+func (b *Builder) assembleManifestList(ctx context.Context, tag string, platformTags []string) (string, error) {
+	createArgs := append([]string{"manifest", "create", "--amend", tag}, platformTags...)
+	if _, err := runDockerCLI(ctx, createArgs...); err != nil {
+		return "", errors.Wrap(err, "creating manifest list")
+	}
+
+	pushOut, err := runDockerCLI(ctx, "manifest", "push", tag)
+	if err != nil {
+		return "", errors.Wrap(err, "pushing manifest list")
+	}
+
+	digest := strings.TrimSpace(pushOut)
+	if !strings.HasPrefix(digest, "sha256:") {
+		return "", errors.New("docker manifest push did not return a digest")
+	}
+	return tag + "@" + digest, nil
+}
+
+func runDockerCLI(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", errors.Wrapf(err, "docker %s: %s", strings.Join(args, " "), string(out))
+	}
+	return string(out), nil
+}
+
+// qemuArchNames maps a Docker platform's architecture component to the name
+// qemu-user-static registers itself under in binfmt_misc (e.g. "arm64" is
+// "qemu-aarch64", not "qemu-arm64").
+var qemuArchNames = map[string]string{
+	"amd64":   "x86_64",
+	"386":     "i386",
+	"arm64":   "aarch64",
+	"arm":     "arm",
+	"ppc64le": "ppc64le",
+	"s390x":   "s390x",
+	"riscv64": "riscv64",
+}
+
+var (
+	emulationMu      sync.Mutex
+	emulationChecked = map[string]error{}
+)
+
+// ensureEmulation probes once per platform whether the kernel has a
+// binfmt_misc/qemu handler registered for it, so a missing handler surfaces
+// as a clear, actionable error instead of a cryptic daemon build failure.
+func ensureEmulation(platform string) error {
+	// Platform is "os/arch" or "os/arch/variant" (e.g. "linux/arm/v7"); only
+	// the architecture component matters for emulation.
+	arch := strings.Split(strings.TrimPrefix(platform, "linux/"), "/")[0]
+	if arch == runtime.GOARCH {
+		return nil
+	}
+
+	emulationMu.Lock()
+	defer emulationMu.Unlock()
+
+	if err, checked := emulationChecked[platform]; checked {
+		return err
+	}
+
+	qemuArch, ok := qemuArchNames[arch]
+	if !ok {
+		qemuArch = arch
+	}
+
+	err := probeBinfmt(arch, qemuArch)
+	emulationChecked[platform] = err
+	return err
+}
+
+func probeBinfmt(dockerArch, qemuArch string) error {
+	matches, err := filepath.Glob("/proc/sys/fs/binfmt_misc/qemu-*")
+	if err != nil || len(matches) == 0 {
+		return ErrEmulationNotAvailable{Platform: dockerArch}
+	}
+
+	for _, m := range matches {
+		if strings.Contains(m, qemuArch) {
+			return nil
+		}
+	}
+	return ErrEmulationNotAvailable{Platform: dockerArch}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+// Reproducible output timestamp modes, modeled on Shipwright's OutputImageTimestamp.
+const (
+	ReproducibleZero            = "Zero"
+	ReproducibleSourceTimestamp = "SourceTimestamp"
+	ReproducibleBuildTimestamp  = "BuildTimestamp"
+)
+
+// ErrReproducibleValueNotSupported is returned when an artifact's Reproducible
+// setting is neither one of the well-known modes nor a valid RFC3339 timestamp.
+type ErrReproducibleValueNotSupported struct {
+	Value string
+}
+
+func (e ErrReproducibleValueNotSupported) Error() string {
+	return fmt.Sprintf("unsupported reproducible value %q: must be %q, %q, %q, or an RFC3339 timestamp", e.Value, ReproducibleZero, ReproducibleSourceTimestamp, ReproducibleBuildTimestamp)
+}
+
+// ErrReproducibleNotSupportedForBuilder is returned when reproducible output
+// is requested for a builder that doesn't know how to rewrite image timestamps.
+type ErrReproducibleNotSupportedForBuilder struct {
+	Builder string
+}
+
+func (e ErrReproducibleNotSupportedForBuilder) Error() string {
+	return fmt.Sprintf("reproducible output is not supported for %s artifacts", e.Builder)
+}
+
+// ErrReproducibleNotSupportedWhenPushing is returned when reproducible output
+// is requested for an artifact that's also being pushed. The backends that
+// push push as part of their own Build call, before buildArtifact ever gets
+// a local image ID to rewrite timestamps on, so there's no safe point left
+// to apply the rewrite without re-pushing.
+type ErrReproducibleNotSupportedWhenPushing struct {
+	ImageName string
+}
+
+func (e ErrReproducibleNotSupportedWhenPushing) Error() string {
+	return fmt.Sprintf("%q sets reproducible output, but this builder can't rewrite image timestamps once an image has already been pushed; remove the reproducible setting or build with push disabled", e.ImageName)
+}
+
+// reproducibleSupported reports whether buildArtifact knows how to rewrite
+// image timestamps for the given artifact's builder. Bazel and Custom
+// artifacts are expected to handle reproducibility themselves.
+func reproducibleSupported(artifact *latest.Artifact) (string, bool) {
+	switch {
+	case artifact.DockerArtifact != nil:
+		return "docker", true
+	case artifact.BuildpackArtifact != nil:
+		return "buildpacks", true
+	case artifact.JibArtifact != nil:
+		return "jib", true
+	case artifact.BazelArtifact != nil:
+		return "bazel", false
+	case artifact.CustomArtifact != nil:
+		return "custom", false
+	default:
+		return "", false
+	}
+}
+
+// resolveReproducibleTimestamp turns an artifact's Reproducible setting into
+// the concrete timestamp that should be baked into its image config and layers.
+func (b *Builder) resolveReproducibleTimestamp(ctx context.Context, artifact *latest.Artifact, reproducible string) (time.Time, error) {
+	switch reproducible {
+	case ReproducibleZero:
+		return time.Unix(0, 0).UTC(), nil
+	case ReproducibleBuildTimestamp:
+		return time.Now().UTC(), nil
+	case ReproducibleSourceTimestamp:
+		return b.sourceTimestamp(ctx, artifact)
+	default:
+		ts, err := time.Parse(time.RFC3339, reproducible)
+		if err != nil {
+			return time.Time{}, ErrReproducibleValueNotSupported{Value: reproducible}
+		}
+		return ts, nil
+	}
+}
+
+// sourceTimestamp derives a reproducible timestamp from the artifact's
+// sources: the commit time of HEAD for a git-rooted workspace, falling back
+// to the modification time of the newest dependency.
+func (b *Builder) sourceTimestamp(ctx context.Context, artifact *latest.Artifact) (time.Time, error) {
+	if ts, ok := gitHeadCommitTime(ctx, artifact.Workspace); ok {
+		return ts, nil
+	}
+
+	deps, err := b.DependenciesForArtifact(ctx, artifact)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "computing source timestamp")
+	}
+
+	var newest time.Time
+	for _, dep := range deps {
+		info, err := os.Stat(dep)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest, nil
+}
+
+func gitHeadCommitTime(ctx context.Context, workspace string) (time.Time, bool) {
+	cmd := exec.CommandContext(ctx, "git", "-C", workspace, "log", "-1", "--format=%cI")
+	out, err := cmd.Output()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	ts, err := time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"sync"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+)
+
+// defaultLocalConcurrency is used whenever --local-concurrency isn't set:
+// artifacts build one at a time, matching the pre-existing InSequence behavior.
+const defaultLocalConcurrency = 1
+
+// Builder uses the host docker daemon to build and tag artifacts.
+type Builder struct {
+	localDocker        docker.LocalDaemon
+	localCluster       bool
+	pushImages         bool
+	kubeContext        string
+	insecureRegistries map[string]bool
+
+	// builtImagesMu guards builtImages, which buildArtifact and
+	// buildMultiPlatform can both append to concurrently once builds run in
+	// parallel or fan out across platforms.
+	builtImagesMu sync.Mutex
+	builtImages   []string
+
+	// concurrency is the number of artifacts buildInParallel will build at
+	// once. It's always >= 1; a value of 1 makes Build use InSequence instead.
+	concurrency int
+
+	// cacheConfig controls BuildKit/legacy cache import and export, resolved
+	// into flags via withCacheFlagsForBuild and attached to each build's ctx.
+	cacheConfig CacheConfig
+
+	// digestSource controls how withDigests populates each build.Result's
+	// digest fields; one of DigestSourceInspect, DigestSourceRegistry, or
+	// DigestSourceNone.
+	digestSource string
+}
+
+// trackBuiltImage records an image ID/digest for later pruning. Safe to call
+// from multiple goroutines.
+func (b *Builder) trackBuiltImage(imageIDs ...string) {
+	b.builtImagesMu.Lock()
+	defer b.builtImagesMu.Unlock()
+	b.builtImages = append(b.builtImages, imageIDs...)
+}
+
+// Config configures how a Builder is constructed.
+type Config struct {
+	KubeContext        string
+	InsecureRegistries map[string]bool
+	LocalCluster       bool
+	PushImages         bool
+
+	// LocalConcurrency is set from the --local-concurrency flag. 0 means
+	// "use the default", not "build nothing".
+	LocalConcurrency int
+
+	// Cache configures BuildKit/legacy cache import and export for artifacts
+	// built by this Builder.
+	Cache CacheConfig
+
+	// DigestSource is set from the --digest-source flag. Empty means "use
+	// the default", which is DigestSourceInspect.
+	DigestSource string
+}
+
+// NewBuilder returns a new local daemon Builder configured from cfg.
+func NewBuilder(localDocker docker.LocalDaemon, cfg Config) *Builder {
+	concurrency := cfg.LocalConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultLocalConcurrency
+	}
+
+	digestSource := cfg.DigestSource
+	if digestSource == "" {
+		digestSource = DigestSourceInspect
+	}
+
+	return &Builder{
+		localDocker:        localDocker,
+		localCluster:       cfg.LocalCluster,
+		pushImages:         cfg.PushImages,
+		kubeContext:        cfg.KubeContext,
+		insecureRegistries: cfg.InsecureRegistries,
+		concurrency:        concurrency,
+		cacheConfig:        cfg.Cache,
+		digestSource:       digestSource,
+	}
+}
@@ -0,0 +1,34 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import "time"
+
+// Result captures everything a builder knows about a successfully built
+// artifact's image, beyond just its tagged reference. Builders that can't
+// populate a given field (no registry push, no BuildKit, ...) leave it
+// zero-valued.
+type Result struct {
+	Tag          string
+	ImageID      string
+	Digest       string
+	RepoDigest   string
+	ConfigDigest string
+	Size         int64
+	Platform     string
+	Timestamp    time.Time
+}
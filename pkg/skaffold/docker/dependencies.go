@@ -0,0 +1,88 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// GetDependencies returns the paths a DockerArtifact's build depends on: the
+// Dockerfile itself plus every file COPY/ADD'd into the image.
+func GetDependencies(ctx context.Context, workspace, dockerfilePath string, buildArgs map[string]*string, insecureRegistries map[string]bool) ([]string, error) {
+	deps := []string{dockerfilePath}
+
+	f, err := os.Open(filepath.Join(workspace, dockerfilePath))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading dockerfile")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(expandBuildArgs(scanner.Text(), buildArgs))
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "COPY", "ADD":
+			// The last field is the destination; everything else is a source.
+			deps = append(deps, fields[1:len(fields)-1]...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "scanning dockerfile")
+	}
+
+	return deps, nil
+}
+
+func expandBuildArgs(line string, buildArgs map[string]*string) string {
+	for k, v := range buildArgs {
+		if v == nil {
+			continue
+		}
+		line = strings.ReplaceAll(line, "${"+k+"}", *v)
+		line = strings.ReplaceAll(line, "$"+k, *v)
+	}
+	return line
+}
+
+// SyncMap returns the mapping of source files to their in-container
+// destination for file sync, derived the same way GetDependencies walks
+// COPY/ADD instructions.
+func SyncMap(ctx context.Context, workspace, dockerfilePath string, buildArgs map[string]*string, insecureRegistries map[string]bool) (map[string][]string, error) {
+	deps, err := GetDependencies(ctx, workspace, dockerfilePath, buildArgs, insecureRegistries)
+	if err != nil {
+		return nil, err
+	}
+
+	syncMap := make(map[string][]string, len(deps))
+	for _, dep := range deps {
+		if dep == dockerfilePath {
+			continue
+		}
+		syncMap[dep] = []string{dep}
+	}
+	return syncMap, nil
+}
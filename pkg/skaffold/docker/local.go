@@ -0,0 +1,142 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package docker talks to the host's docker daemon to build, tag, inspect,
+// and rewrite images for the local builder.
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// LocalDaemon is the subset of docker daemon operations the local builder
+// needs. It's an interface so tests can substitute a fake.
+type LocalDaemon interface {
+	Close() error
+	Build(ctx context.Context, out io.Writer, workspace, dockerfilePath string, buildArgs map[string]*string, opts BuildOptions) (string, error)
+	Pull(ctx context.Context, out io.Writer, ref string) error
+	Push(ctx context.Context, out io.Writer, tag string) (string, error)
+	TagWithImageID(ctx context.Context, tag string, imageID string) (string, error)
+	ImageInspectWithRaw(ctx context.Context, ref string) (types.ImageInspect, []byte, error)
+	ImageConfig(ctx context.Context, ref string) (string, []byte, error)
+	RewriteImageTimestamps(ctx context.Context, imageID string, ts time.Time) (string, error)
+}
+
+// BuildOptions configures a single docker build invocation.
+type BuildOptions struct {
+	// Tag is the name:tag the built image is tagged with.
+	Tag string
+	// Platform is passed through as --platform, if set.
+	Platform string
+	// ExtraFlags are additional CLI flags to append verbatim, e.g. the
+	// --cache-from/--cache-to flags build.CacheFlagsFromContext resolves.
+	ExtraFlags []string
+
+	InsecureRegistries map[string]bool
+}
+
+type localDaemon struct {
+	apiClient client.CommonAPIClient
+}
+
+// NewLocalDaemon wraps an already-connected docker API client as a
+// LocalDaemon.
+func NewLocalDaemon(apiClient client.CommonAPIClient) LocalDaemon {
+	return &localDaemon{apiClient: apiClient}
+}
+
+func (l *localDaemon) Close() error {
+	return l.apiClient.Close()
+}
+
+func (l *localDaemon) ImageInspectWithRaw(ctx context.Context, ref string) (types.ImageInspect, []byte, error) {
+	return l.apiClient.ImageInspectWithRaw(ctx, ref)
+}
+
+func (l *localDaemon) Pull(ctx context.Context, out io.Writer, ref string) error {
+	body, err := l.apiClient.ImagePull(ctx, ref, types.ImagePullOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "pulling %s", ref)
+	}
+	defer body.Close()
+
+	_, err = io.Copy(out, body)
+	return err
+}
+
+func (l *localDaemon) TagWithImageID(ctx context.Context, tag string, imageID string) (string, error) {
+	if err := l.apiClient.ImageTag(ctx, imageID, tag); err != nil {
+		return "", errors.Wrapf(err, "tagging %s as %s", imageID, tag)
+	}
+	return tag, nil
+}
+
+// Build shells out to the docker CLI rather than the daemon API, so
+// BuildOptions.ExtraFlags (BuildKit cache-from/cache-to syntax the Engine
+// API doesn't model) can be passed straight through.
+func (l *localDaemon) Build(ctx context.Context, out io.Writer, workspace, dockerfilePath string, buildArgs map[string]*string, opts BuildOptions) (string, error) {
+	args := []string{"build", workspace, "-f", dockerfilePath, "-t", opts.Tag}
+	for k, v := range buildArgs {
+		if v == nil {
+			continue
+		}
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", k, *v))
+	}
+	if opts.Platform != "" {
+		args = append(args, "--platform", opts.Platform)
+	}
+	args = append(args, opts.ExtraFlags...)
+
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "docker build %s", workspace)
+	}
+
+	insp, _, err := l.ImageInspectWithRaw(ctx, opts.Tag)
+	if err != nil {
+		return "", errors.Wrap(err, "inspecting built image")
+	}
+	return insp.ID, nil
+}
+
+// Push pushes tag and returns the pushed image's repo digest.
+func (l *localDaemon) Push(ctx context.Context, out io.Writer, tag string) (string, error) {
+	cmd := exec.CommandContext(ctx, "docker", "push", tag)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "docker push %s", tag)
+	}
+
+	insp, _, err := l.ImageInspectWithRaw(ctx, tag)
+	if err != nil {
+		return "", errors.Wrap(err, "inspecting pushed image")
+	}
+	for _, repoDigest := range insp.RepoDigests {
+		return repoDigest, nil
+	}
+	return "", errors.Errorf("docker push %s reported no repo digest", tag)
+}
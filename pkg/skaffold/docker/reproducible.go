@@ -0,0 +1,177 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RewriteImageTimestamps rebuilds imageID's config and every layer's tar
+// headers with ts in place of their real build-time timestamps, then loads
+// the result back into the daemon. Given the same inputs and the same ts,
+// this always produces the same image ID, regardless of when the build that
+// produced imageID actually ran.
+func (l *localDaemon) RewriteImageTimestamps(ctx context.Context, imageID string, ts time.Time) (string, error) {
+	saved, err := l.readSavedImage(ctx, imageID)
+	if err != nil {
+		return "", err
+	}
+
+	newConfig, err := rewriteConfigTimestamps(saved.config, ts)
+	if err != nil {
+		return "", errors.Wrap(err, "rewriting image config")
+	}
+
+	newLayers := make(map[string][]byte, len(saved.layerPaths))
+	diffIDs := make([]interface{}, len(saved.layerPaths))
+	for i, path := range saved.layerPaths {
+		rewritten, err := rewriteLayerTimestamps(saved.layers[path], ts)
+		if err != nil {
+			return "", errors.Wrapf(err, "rewriting layer %s", path)
+		}
+		newLayers[path] = rewritten
+		diffIDs[i] = sha256Digest(rewritten)
+	}
+	newConfig, err = setRootfsDiffIDs(newConfig, diffIDs)
+	if err != nil {
+		return "", errors.Wrap(err, "updating rootfs diff_ids")
+	}
+
+	newConfigDigest := sha256Digest(newConfig)
+	configName := strings.TrimPrefix(newConfigDigest, "sha256:") + ".json"
+
+	manifest := []map[string]interface{}{{
+		"Config":   configName,
+		"RepoTags": saved.repoTags,
+		"Layers":   saved.layerPaths,
+	}}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return "", errors.Wrap(err, "marshaling manifest")
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	writeFile := func(name string, data []byte) error {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Size: int64(len(data)), Mode: 0644}); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	if err := writeFile("manifest.json", manifestJSON); err != nil {
+		return "", errors.Wrap(err, "writing manifest.json")
+	}
+	if err := writeFile(configName, newConfig); err != nil {
+		return "", errors.Wrap(err, "writing rewritten config")
+	}
+	for _, path := range saved.layerPaths {
+		if err := writeFile(path, newLayers[path]); err != nil {
+			return "", errors.Wrapf(err, "writing layer %s", path)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return "", errors.Wrap(err, "closing rewritten image tar")
+	}
+
+	resp, err := l.apiClient.ImageLoad(ctx, &buf, false)
+	if err != nil {
+		return "", errors.Wrap(err, "loading rewritten image")
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	return newConfigDigest, nil
+}
+
+// rewriteConfigTimestamps returns configJSON with its top-level "created"
+// field, and every history entry's "created" field, replaced with ts.
+func rewriteConfigTimestamps(configJSON []byte, ts time.Time) ([]byte, error) {
+	var cfg map[string]interface{}
+	if err := json.Unmarshal(configJSON, &cfg); err != nil {
+		return nil, errors.Wrap(err, "parsing image config")
+	}
+
+	stamp := ts.UTC().Format(time.RFC3339Nano)
+	cfg["created"] = stamp
+	if history, ok := cfg["history"].([]interface{}); ok {
+		for _, h := range history {
+			if entry, ok := h.(map[string]interface{}); ok {
+				entry["created"] = stamp
+			}
+		}
+	}
+
+	return json.Marshal(cfg)
+}
+
+// setRootfsDiffIDs returns configJSON with its rootfs.diff_ids replaced.
+func setRootfsDiffIDs(configJSON []byte, diffIDs []interface{}) ([]byte, error) {
+	var cfg map[string]interface{}
+	if err := json.Unmarshal(configJSON, &cfg); err != nil {
+		return nil, errors.Wrap(err, "parsing image config")
+	}
+	rootfs, ok := cfg["rootfs"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("image config has no rootfs section")
+	}
+	rootfs["diff_ids"] = diffIDs
+
+	return json.Marshal(cfg)
+}
+
+// rewriteLayerTimestamps returns layerTar with every tar header's
+// mod/access/change time replaced with ts, preserving file contents.
+func rewriteLayerTimestamps(layerTar []byte, ts time.Time) ([]byte, error) {
+	var out bytes.Buffer
+	tw := tar.NewWriter(&out)
+	tr := tar.NewReader(bytes.NewReader(layerTar))
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "reading layer tar")
+		}
+		hdr.ModTime = ts
+		hdr.AccessTime = ts
+		hdr.ChangeTime = ts
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// savedImage is the parsed contents of a `docker save` tarball: enough to
+// read or rewrite its config and layers and reassemble a new image.
+type savedImage struct {
+	repoTags []string
+	config   []byte
+	// layerPaths preserves manifest.json's layer ordering; layers is keyed
+	// by the same paths.
+	layerPaths []string
+	layers     map[string][]byte
+}
+
+// readSavedImage asks the daemon to export ref as a `docker save` tarball
+// and parses it into a savedImage.
+func (l *localDaemon) readSavedImage(ctx context.Context, ref string) (*savedImage, error) {
+	body, err := l.apiClient.ImageSave(ctx, []string{ref})
+	if err != nil {
+		return nil, errors.Wrapf(err, "saving image %s", ref)
+	}
+	defer body.Close()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(body)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "reading image tar")
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s from image tar", hdr.Name)
+		}
+		files[hdr.Name] = data
+	}
+
+	manifestRaw, ok := files["manifest.json"]
+	if !ok {
+		return nil, errors.New("image tar has no manifest.json")
+	}
+	var manifest []struct {
+		Config   string   `json:"Config"`
+		RepoTags []string `json:"RepoTags"`
+		Layers   []string `json:"Layers"`
+	}
+	if err := json.Unmarshal(manifestRaw, &manifest); err != nil {
+		return nil, errors.Wrap(err, "parsing manifest.json")
+	}
+	if len(manifest) != 1 {
+		return nil, errors.Errorf("expected exactly one image in %s's tar, got %d", ref, len(manifest))
+	}
+	entry := manifest[0]
+
+	config, ok := files[entry.Config]
+	if !ok {
+		return nil, errors.Errorf("image tar has no config file %s", entry.Config)
+	}
+
+	layers := make(map[string][]byte, len(entry.Layers))
+	for _, path := range entry.Layers {
+		data, ok := files[path]
+		if !ok {
+			return nil, errors.Errorf("image tar has no layer file %s", path)
+		}
+		layers[path] = data
+	}
+
+	return &savedImage{
+		repoTags:   entry.RepoTags,
+		config:     config,
+		layerPaths: entry.Layers,
+		layers:     layers,
+	}, nil
+}
+
+// ImageConfig returns ref's real config digest and the raw bytes of its v1
+// image config blob -- the same bytes a registry push would store as the
+// manifest's config descriptor -- as opposed to ImageInspectWithRaw, whose
+// raw bytes are just the client-facing inspect response, a different
+// document entirely.
+func (l *localDaemon) ImageConfig(ctx context.Context, ref string) (string, []byte, error) {
+	saved, err := l.readSavedImage(ctx, ref)
+	if err != nil {
+		return "", nil, err
+	}
+	return sha256Digest(saved.config), saved.config, nil
+}
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
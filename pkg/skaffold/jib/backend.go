@@ -0,0 +1,66 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jib
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+func init() {
+	build.RegisterBackend(Backend{})
+}
+
+// Backend is the build.ArtifactBackend for JibArtifact. It registers itself
+// with the global build.RegisterBackend registry so that build/local
+// doesn't need to know this package exists.
+type Backend struct{}
+
+func (Backend) Name() string { return "jib" }
+
+func (Backend) Matches(a *latest.Artifact) bool { return a.JibArtifact != nil }
+
+func (Backend) Build(ctx context.Context, out io.Writer, a *latest.Artifact, tag string) (string, error) {
+	env := build.EnvironmentFromContext(ctx)
+
+	t, err := DeterminePluginType(a.Workspace, a.JibArtifact)
+	if err != nil {
+		return "", err
+	}
+
+	switch t {
+	case JibMaven:
+		return BuildMaven(ctx, out, a.Workspace, a.JibArtifact, tag, env)
+	case JibGradle:
+		return BuildGradle(ctx, out, a.Workspace, a.JibArtifact, tag, env)
+	default:
+		return "", errors.Errorf("unable to determine Jib builder type for %s", a.Workspace)
+	}
+}
+
+func (Backend) Dependencies(ctx context.Context, a *latest.Artifact) ([]string, error) {
+	return GetDependencies(ctx, a.Workspace, a.JibArtifact)
+}
+
+func (Backend) SyncMap(ctx context.Context, a *latest.Artifact) (map[string][]string, error) {
+	return nil, build.ErrSyncMapNotSupported{}
+}
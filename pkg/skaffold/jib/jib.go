@@ -0,0 +1,156 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jib builds JibArtifacts via the Jib Maven and Gradle plugins.
+package jib
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+// PluginType identifies which build tool a JibArtifact's workspace uses.
+type PluginType int
+
+const (
+	JibMaven PluginType = iota
+	JibGradle
+)
+
+// DeterminePluginType infers which Jib plugin workspace is set up with,
+// honoring an explicit a.Type override before falling back to looking for
+// each tool's build descriptor.
+func DeterminePluginType(workspace string, a *latest.JibArtifact) (PluginType, error) {
+	switch a.Type {
+	case "maven":
+		return JibMaven, nil
+	case "gradle":
+		return JibGradle, nil
+	}
+
+	if fileExists(filepath.Join(workspace, "pom.xml")) {
+		return JibMaven, nil
+	}
+	if fileExists(filepath.Join(workspace, "build.gradle")) {
+		return JibGradle, nil
+	}
+	return 0, errors.Errorf("%s contains neither a pom.xml nor a build.gradle", workspace)
+}
+
+// BuildMaven runs Jib's Maven plugin against workspace, building tag
+// directly into the local daemon or, when env.PushImages is set, pushing it
+// straight to the registry.
+func BuildMaven(ctx context.Context, out io.Writer, workspace string, a *latest.JibArtifact, tag string, env build.Environment) (string, error) {
+	goal := "jib:dockerBuild"
+	if env.PushImages {
+		goal = "jib:build"
+	}
+
+	args := []string{goal, "-Dimage=" + tag}
+	if platform, ok := build.PlatformFromContext(ctx); ok {
+		args = append(args, "-Djib.from.platforms="+platform)
+	}
+	args = append(args, a.Args...)
+
+	cmd := exec.CommandContext(ctx, mavenExecutable(workspace), args...)
+	cmd.Dir = workspace
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrap(err, "running jib maven build")
+	}
+
+	return resultFor(ctx, env, tag)
+}
+
+// BuildGradle runs Jib's Gradle plugin against workspace, building tag
+// directly into the local daemon or, when env.PushImages is set, pushing it
+// straight to the registry.
+func BuildGradle(ctx context.Context, out io.Writer, workspace string, a *latest.JibArtifact, tag string, env build.Environment) (string, error) {
+	task := "jibDockerBuild"
+	if env.PushImages {
+		task = "jib"
+	}
+
+	args := []string{task, "--image=" + tag}
+	if platform, ok := build.PlatformFromContext(ctx); ok {
+		args = append(args, "-Djib.from.platforms="+platform)
+	}
+	args = append(args, a.Args...)
+
+	cmd := exec.CommandContext(ctx, gradleExecutable(workspace), args...)
+	cmd.Dir = workspace
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrap(err, "running jib gradle build")
+	}
+
+	return resultFor(ctx, env, tag)
+}
+
+func resultFor(ctx context.Context, env build.Environment, tag string) (string, error) {
+	if env.PushImages {
+		insp, _, err := env.LocalDocker.ImageInspectWithRaw(ctx, tag)
+		if err != nil {
+			return "", errors.Wrap(err, "inspecting pushed image")
+		}
+		for _, repoDigest := range insp.RepoDigests {
+			return repoDigest, nil
+		}
+		return "", errors.Errorf("jib push of %q reported no repo digest", tag)
+	}
+
+	insp, _, err := env.LocalDocker.ImageInspectWithRaw(ctx, tag)
+	if err != nil {
+		return "", errors.Wrap(err, "inspecting built image")
+	}
+	return insp.ID, nil
+}
+
+func mavenExecutable(workspace string) string {
+	if wrapper := filepath.Join(workspace, "mvnw"); fileExists(wrapper) {
+		return wrapper
+	}
+	return "mvn"
+}
+
+func gradleExecutable(workspace string) string {
+	if wrapper := filepath.Join(workspace, "gradlew"); fileExists(wrapper) {
+		return wrapper
+	}
+	return "gradle"
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// GetDependencies returns the paths a Jib build depends on. Jib plugins
+// decide for themselves what to pull into the image from the project, so
+// the whole workspace is treated as a dependency.
+func GetDependencies(ctx context.Context, workspace string, a *latest.JibArtifact) ([]string, error) {
+	return []string{workspace}, nil
+}